@@ -0,0 +1,213 @@
+package device
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/patchwork-toolkit/patchwork/catalog/httputil"
+)
+
+// URL pattern placeholders in bmizerany/pat syntax. The handlers read the
+// matched values from req.URL.Query() under these names, which is where pat
+// stores them; a mux that does not extract path parameters
+// (net/http.ServeMux) leaves them empty.
+const (
+	PatternUuid   = ":uuid"
+	PatternReg    = ":reg"
+	PatternRes    = ":res"
+	PatternFType  = ":type"
+	PatternFPath  = ":path"
+	PatternFOp    = ":op"
+	PatternFValue = ":value"
+)
+
+// defaultPageSize is used when a list request does not specify ?n=.
+const defaultPageSize = 100
+
+// CatalogAPI exposes a CatalogStorage over HTTP. It mounts nothing itself,
+// and instances from either constructor have every handler: a caller
+// building a read-only catalog (NewReadableCatalogAPI) mounts only
+// List/Get/GetResource/Filter/Events, a writable one (NewWritableCatalogAPI)
+// additionally mounts Add/Update/Delete.
+type CatalogAPI struct {
+	storage     CatalogStorage
+	location    string
+	staticLoc   string
+	description string
+}
+
+func NewReadableCatalogAPI(storage CatalogStorage, location, staticLocation, description string) *CatalogAPI {
+	return &CatalogAPI{
+		storage:     storage,
+		location:    location,
+		staticLoc:   staticLocation,
+		description: description,
+	}
+}
+
+func NewWritableCatalogAPI(storage CatalogStorage, staticLocation string) *CatalogAPI {
+	return &CatalogAPI{
+		storage:   storage,
+		staticLoc: staticLocation,
+	}
+}
+
+// List serves GET /catalog?n=<page-size>&last=<last-id>, returning a page
+// of devices and, if more entries remain, a Link: <...>; rel="next" header
+// (c.f. the Docker Distribution _catalog pagination semantics).
+func (self *CatalogAPI) List(rw http.ResponseWriter, req *http.Request) {
+	n := defaultPageSize
+	if v := req.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			httputil.RespondWithError(rw, http.StatusBadRequest, "n must be a positive integer")
+			return
+		}
+		n = parsed
+	}
+	last := req.URL.Query().Get("last")
+
+	devices, hasMore, err := self.storage.List(n, last)
+	if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if hasMore && len(devices) > 0 {
+		next := req.URL.Path + "?n=" + strconv.Itoa(n) + "&last=" + devices[len(devices)-1].Id
+		rw.Header().Set("Link", "<"+next+">; rel=\"next\"")
+	}
+	httputil.RespondWithJSON(rw, devices)
+}
+
+func (self *CatalogAPI) Get(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":uuid")
+	d, err := self.storage.Get(id)
+	if err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Device not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.RespondWithJSON(rw, d)
+}
+
+func (self *CatalogAPI) GetResource(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":uuid")
+	resName := req.URL.Query().Get(":res")
+	r, err := self.storage.GetResource(id, resName)
+	if err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Resource not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.RespondWithJSON(rw, r)
+}
+
+func (self *CatalogAPI) Filter(rw http.ResponseWriter, req *http.Request) {
+	path := req.URL.Query().Get(":path")
+	op := req.URL.Query().Get(":op")
+	value := req.URL.Query().Get(":value")
+
+	devices, err := self.storage.Filter(path, op, value)
+	if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.RespondWithJSON(rw, devices)
+}
+
+func (self *CatalogAPI) Add(rw http.ResponseWriter, req *http.Request) {
+	var d Device
+	if err := json.NewDecoder(req.Body).Decode(&d); err != nil {
+		httputil.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Body.Close()
+
+	if err := self.storage.Add(d); err == ErrAlreadyExists {
+		httputil.RespondWithError(rw, http.StatusConflict, "Device already exists")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (self *CatalogAPI) Update(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":uuid")
+
+	var d Device
+	if err := json.NewDecoder(req.Body).Decode(&d); err != nil {
+		httputil.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Body.Close()
+
+	if err := self.storage.Update(id, d); err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Device not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (self *CatalogAPI) Delete(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":uuid")
+
+	if err := self.storage.Delete(id); err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Device not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Events serves GET /catalog/events, streaming add/update/delete
+// notifications as newline-delimited JSON (or as an SSE stream when the
+// client asks for text/event-stream) until the client disconnects.
+func (self *CatalogAPI) Events(rw http.ResponseWriter, req *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	events, cancel := self.storage.Subscribe()
+	defer cancel()
+
+	sse := req.Header.Get("Accept") == "text/event-stream"
+	if sse {
+		rw.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	bw := bufio.NewWriter(rw)
+	notify := req.Context().Done()
+	for {
+		select {
+		case e, open := <-events:
+			if !open {
+				return
+			}
+			if err := httputil.StreamEvents(bw, flusher, sse, e); err != nil {
+				continue
+			}
+		case <-notify:
+			return
+		}
+	}
+}
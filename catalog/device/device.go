@@ -0,0 +1,36 @@
+package device
+
+// Resource describes a single capability exposed by a device (e.g. a sensor
+// reading or an actuator).
+type Resource struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Device is the catalog entry for a single registered device gateway
+// resource owner.
+type Device struct {
+	Id          string     `json:"id"`
+	Type        string     `json:"type,omitempty"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Resources   []Resource `json:"resources,omitempty"`
+	Ttl         int        `json:"ttl,omitempty"`
+}
+
+// EventType identifies the kind of change that happened to a catalog entry.
+type EventType string
+
+const (
+	EventTypeCreate EventType = "create"
+	EventTypeUpdate EventType = "update"
+	EventTypeDelete EventType = "delete"
+)
+
+// Event is a single catalog change notification delivered to subscribers of
+// the /catalog/events stream.
+type Event struct {
+	Type   EventType `json:"type"`
+	Id     string    `json:"id"`
+	Device *Device   `json:"device,omitempty"`
+}
@@ -0,0 +1,204 @@
+package device
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/patchwork-toolkit/patchwork/catalog/httputil"
+)
+
+var ErrNotFound = errors.New("device: entry not found")
+var ErrAlreadyExists = errors.New("device: entry already exists")
+
+// subscriberBacklog bounds how many unconsumed events a single subscriber
+// may accumulate; once its buffer is full, further events are dropped for
+// that subscriber (it stays subscribed) rather than letting one stalled
+// client back-pressure the whole catalog.
+const subscriberBacklog = 64
+
+// CatalogStorage is the backing store for a device catalog. Implementations
+// must be safe for concurrent use.
+type CatalogStorage interface {
+	Add(d Device) error
+	Update(id string, d Device) error
+	Delete(id string) error
+	Get(id string) (Device, error)
+	GetResource(id, resourceName string) (Resource, error)
+	// List returns up to n devices with Id greater than last (or from the
+	// beginning when last is empty), in ascending Id order, followed by
+	// whether more entries remain beyond the returned page.
+	List(n int, last string) (devices []Device, hasMore bool, err error)
+	Filter(path, op, value string) ([]Device, error)
+	// Subscribe registers a new listener for catalog change events. The
+	// returned cancel function must be called to release the subscription.
+	Subscribe() (events <-chan Event, cancel func())
+}
+
+// CatalogMemoryStorage is an in-memory CatalogStorage backed by a sorted
+// slice of ids, broadcasting every mutation to subscribers.
+type CatalogMemoryStorage struct {
+	mutex sync.RWMutex
+	data  map[string]Device
+	ids   []string
+
+	subMutex sync.Mutex
+	subs     map[chan Event]struct{}
+}
+
+func NewCatalogMemoryStorage() *CatalogMemoryStorage {
+	return &CatalogMemoryStorage{
+		data: make(map[string]Device),
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+func (self *CatalogMemoryStorage) Add(d Device) error {
+	self.mutex.Lock()
+	if _, exists := self.data[d.Id]; exists {
+		self.mutex.Unlock()
+		return ErrAlreadyExists
+	}
+	self.ids = append(self.ids, d.Id)
+	sort.Strings(self.ids)
+	self.data[d.Id] = d
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeCreate, Id: d.Id, Device: &d})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Update(id string, d Device) error {
+	self.mutex.Lock()
+	if _, exists := self.data[id]; !exists {
+		self.mutex.Unlock()
+		return ErrNotFound
+	}
+	d.Id = id
+	self.data[id] = d
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeUpdate, Id: id, Device: &d})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Delete(id string) error {
+	self.mutex.Lock()
+	if _, exists := self.data[id]; !exists {
+		self.mutex.Unlock()
+		return ErrNotFound
+	}
+	delete(self.data, id)
+	for i, existing := range self.ids {
+		if existing == id {
+			self.ids = append(self.ids[:i], self.ids[i+1:]...)
+			break
+		}
+	}
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeDelete, Id: id})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Get(id string) (Device, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	d, exists := self.data[id]
+	if !exists {
+		return Device{}, ErrNotFound
+	}
+	return d, nil
+}
+
+func (self *CatalogMemoryStorage) GetResource(id, resourceName string) (Resource, error) {
+	d, err := self.Get(id)
+	if err != nil {
+		return Resource{}, err
+	}
+	for _, r := range d.Resources {
+		if r.Name == resourceName {
+			return r, nil
+		}
+	}
+	return Resource{}, ErrNotFound
+}
+
+func (self *CatalogMemoryStorage) List(n int, last string) ([]Device, bool, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	start, end, hasMore := httputil.PageBounds(self.ids, n, last)
+
+	devices := make([]Device, 0, end-start)
+	for _, id := range self.ids[start:end] {
+		devices = append(devices, self.data[id])
+	}
+	return devices, hasMore, nil
+}
+
+func (self *CatalogMemoryStorage) Filter(path, op, value string) ([]Device, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	var matches []Device
+	for _, id := range self.ids {
+		d := self.data[id]
+		v, ok := fieldByPath(d, path)
+		if !ok {
+			continue
+		}
+		if httputil.MatchOp(v, op, value) {
+			matches = append(matches, d)
+		}
+	}
+	return matches, nil
+}
+
+func (self *CatalogMemoryStorage) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBacklog)
+
+	self.subMutex.Lock()
+	self.subs[ch] = struct{}{}
+	self.subMutex.Unlock()
+
+	cancel := func() {
+		self.subMutex.Lock()
+		if _, ok := self.subs[ch]; ok {
+			delete(self.subs, ch)
+			close(ch)
+		}
+		self.subMutex.Unlock()
+	}
+	return ch, cancel
+}
+
+// broadcast fans an event out to every subscriber. A subscriber that isn't
+// keeping up has the event dropped for it rather than stalling the
+// publisher or the other subscribers.
+func (self *CatalogMemoryStorage) broadcast(e Event) {
+	self.subMutex.Lock()
+	defer self.subMutex.Unlock()
+	for ch := range self.subs {
+		select {
+		case ch <- e:
+		default:
+			// slow subscriber: drop this event for it
+		}
+	}
+}
+
+func fieldByPath(d Device, path string) (string, bool) {
+	switch path {
+	case "id":
+		return d.Id, true
+	case "type":
+		return d.Type, true
+	case "name":
+		return d.Name, true
+	case "description":
+		return d.Description, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,88 @@
+package device
+
+import "testing"
+
+func TestCatalogMemoryStorageListPagination(t *testing.T) {
+	s := NewCatalogMemoryStorage()
+	for _, id := range []string{"d1", "d2", "d3", "d4", "d5"} {
+		if err := s.Add(Device{Id: id}); err != nil {
+			t.Fatalf("Add(%s): %v", id, err)
+		}
+	}
+
+	page1, hasMore, err := s.List(2, "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !hasMore {
+		t.Fatalf("List: expected hasMore=true for first page")
+	}
+	if got := ids(page1); got != "d1,d2" {
+		t.Errorf("List first page = %v, want d1,d2", got)
+	}
+
+	page2, hasMore, err := s.List(2, page1[len(page1)-1].Id)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !hasMore {
+		t.Fatalf("List: expected hasMore=true for second page")
+	}
+	if got := ids(page2); got != "d3,d4" {
+		t.Errorf("List second page = %v, want d3,d4", got)
+	}
+
+	page3, hasMore, err := s.List(2, page2[len(page2)-1].Id)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if hasMore {
+		t.Errorf("List: expected hasMore=false for last page")
+	}
+	if got := ids(page3); got != "d5" {
+		t.Errorf("List last page = %v, want d5", got)
+	}
+}
+
+func TestCatalogMemoryStorageAddDuplicate(t *testing.T) {
+	s := NewCatalogMemoryStorage()
+	if err := s.Add(Device{Id: "d1", Name: "first"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add(Device{Id: "d1", Name: "second"}); err != ErrAlreadyExists {
+		t.Fatalf("Add duplicate: got %v, want ErrAlreadyExists", err)
+	}
+
+	d, err := s.Get("d1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if d.Name != "first" {
+		t.Errorf("Add duplicate must not overwrite: Name = %q, want %q", d.Name, "first")
+	}
+}
+
+func TestCatalogMemoryStorageFilterContains(t *testing.T) {
+	s := NewCatalogMemoryStorage()
+	s.Add(Device{Id: "d1", Name: "kitchen-sensor"})
+	s.Add(Device{Id: "d2", Name: "garage-sensor"})
+
+	matches, err := s.Filter("name", "contains", "kitchen")
+	if err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Id != "d1" {
+		t.Errorf("Filter contains = %v, want [d1]", matches)
+	}
+}
+
+func ids(devices []Device) string {
+	s := ""
+	for i, d := range devices {
+		if i > 0 {
+			s += ","
+		}
+		s += d.Id
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+// Package httputil holds the HTTP response, pagination and event-streaming
+// plumbing shared by catalog/device and catalog/service, so the two
+// catalog implementations only differ in their storage and entry types,
+// not in how they talk HTTP.
+package httputil
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+func RespondWithJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	b, err := json.Marshal(v)
+	if err != nil {
+		RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.Write(b)
+}
+
+func RespondWithError(rw http.ResponseWriter, status int, msg string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	b, _ := json.Marshal(map[string]string{"error": msg})
+	rw.Write(b)
+}
+
+// PageBounds computes the [start, end) slice bounds of a page into the
+// sorted, deduplicated id list ids: up to n ids following (but not
+// including) last, and whether more ids remain past end. A non-positive n
+// means "the rest of the list".
+func PageBounds(ids []string, n int, last string) (start, end int, hasMore bool) {
+	start = 0
+	if last != "" {
+		start = sort.SearchStrings(ids, last)
+		if start < len(ids) && ids[start] == last {
+			start++
+		}
+	}
+
+	if n <= 0 {
+		n = len(ids) - start
+	}
+
+	end = start + n
+	hasMore = end < len(ids)
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return start, end, hasMore
+}
+
+// MatchOp evaluates a simple field-filter comparison, shared by every
+// catalog's Filter handler.
+func MatchOp(v, op, value string) bool {
+	switch op {
+	case "equals":
+		return v == value
+	case "contains":
+		return indexOf(v, value) >= 0
+	default:
+		return false
+	}
+}
+
+func indexOf(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return i
+		}
+	}
+	return -1
+}
+
+// StreamEvents writes marshal(v) to bw as either a newline-delimited JSON
+// or (when sse is true) a text/event-stream frame, flushing immediately so
+// each event reaches the client as it happens.
+func StreamEvents(bw *bufio.Writer, flusher http.Flusher, sse bool, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if sse {
+		bw.WriteString("data: ")
+	}
+	bw.Write(b)
+	bw.WriteString("\n")
+	if sse {
+		// a blank line ends the SSE event, so the client dispatches it now
+		bw.WriteString("\n")
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/patchwork-toolkit/patchwork/catalog/httputil"
+)
+
+const (
+	CatalogBaseUrl = "/dns-sd/0.1"
+
+	PatternHostid = ":hostid"
+	PatternReg    = ":reg"
+	PatternFType  = ":type"
+	PatternFPath  = ":path"
+	PatternFOp    = ":op"
+	PatternFValue = ":value"
+)
+
+const defaultPageSize = 100
+
+// CatalogAPI exposes a CatalogStorage over HTTP. NewWritableCatalogAPI is
+// used by the standalone service catalog; NewReadableCatalogAPI can be used
+// by anything that only needs to browse registrations.
+type CatalogAPI struct {
+	storage   CatalogStorage
+	staticCtx string
+}
+
+func NewReadableCatalogAPI(storage CatalogStorage, staticCtx string) *CatalogAPI {
+	return &CatalogAPI{storage: storage, staticCtx: staticCtx}
+}
+
+func NewWritableCatalogAPI(storage CatalogStorage, staticCtx string) *CatalogAPI {
+	return &CatalogAPI{storage: storage, staticCtx: staticCtx}
+}
+
+// List serves GET /dns-sd/0.1?n=<page-size>&last=<last-host>, returning a
+// page of services and a Link: <...>; rel="next" header when more entries
+// exist.
+func (self *CatalogAPI) List(rw http.ResponseWriter, req *http.Request) {
+	n := defaultPageSize
+	if v := req.URL.Query().Get("n"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 {
+			httputil.RespondWithError(rw, http.StatusBadRequest, "n must be a positive integer")
+			return
+		}
+		n = parsed
+	}
+	last := req.URL.Query().Get("last")
+
+	services, hasMore, err := self.storage.List(n, last)
+	if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if hasMore && len(services) > 0 {
+		next := req.URL.Path + "?n=" + strconv.Itoa(n) + "&last=" + services[len(services)-1].Host
+		rw.Header().Set("Link", "<"+next+">; rel=\"next\"")
+	}
+	httputil.RespondWithJSON(rw, services)
+}
+
+func (self *CatalogAPI) Get(rw http.ResponseWriter, req *http.Request) {
+	host := req.URL.Query().Get(":hostid")
+	s, err := self.storage.Get(host)
+	if err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Service not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.RespondWithJSON(rw, s)
+}
+
+func (self *CatalogAPI) Filter(rw http.ResponseWriter, req *http.Request) {
+	path := req.URL.Query().Get(":path")
+	op := req.URL.Query().Get(":op")
+	value := req.URL.Query().Get(":value")
+
+	services, err := self.storage.Filter(path, op, value)
+	if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.RespondWithJSON(rw, services)
+}
+
+func (self *CatalogAPI) Add(rw http.ResponseWriter, req *http.Request) {
+	var s Service
+	if err := json.NewDecoder(req.Body).Decode(&s); err != nil {
+		httputil.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Body.Close()
+
+	if err := self.storage.Add(s); err == ErrAlreadyExists {
+		httputil.RespondWithError(rw, http.StatusConflict, "Service already exists")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusCreated)
+}
+
+func (self *CatalogAPI) Update(rw http.ResponseWriter, req *http.Request) {
+	host := req.URL.Query().Get(":hostid")
+
+	var s Service
+	if err := json.NewDecoder(req.Body).Decode(&s); err != nil {
+		httputil.RespondWithError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Body.Close()
+
+	if err := self.storage.Update(host, s); err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Service not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (self *CatalogAPI) Delete(rw http.ResponseWriter, req *http.Request) {
+	host := req.URL.Query().Get(":hostid")
+
+	if err := self.storage.Delete(host); err == ErrNotFound {
+		httputil.RespondWithError(rw, http.StatusNotFound, "Service not found")
+		return
+	} else if err != nil {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Events serves GET /dns-sd/0.1/events, streaming add/update/delete
+// notifications as newline-delimited JSON (or SSE when the client asks for
+// text/event-stream) until the client disconnects.
+func (self *CatalogAPI) Events(rw http.ResponseWriter, req *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		httputil.RespondWithError(rw, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	events, cancel := self.storage.Subscribe()
+	defer cancel()
+
+	sse := req.Header.Get("Accept") == "text/event-stream"
+	if sse {
+		rw.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	bw := bufio.NewWriter(rw)
+	notify := req.Context().Done()
+	for {
+		select {
+		case e, open := <-events:
+			if !open {
+				return
+			}
+			if err := httputil.StreamEvents(bw, flusher, sse, e); err != nil {
+				continue
+			}
+		case <-notify:
+			return
+		}
+	}
+}
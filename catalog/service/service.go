@@ -0,0 +1,28 @@
+package service
+
+// Service is a single service-catalog registration, keyed by the
+// registering host's id.
+type Service struct {
+	Id          string `json:"id"`
+	Host        string `json:"host"`
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Ttl         int     `json:"ttl,omitempty"`
+}
+
+type EventType string
+
+const (
+	EventTypeCreate EventType = "create"
+	EventTypeUpdate EventType = "update"
+	EventTypeDelete EventType = "delete"
+)
+
+// Event is a single catalog change notification delivered to subscribers of
+// the /events stream.
+type Event struct {
+	Type    EventType `json:"type"`
+	Host    string    `json:"host"`
+	Service *Service  `json:"service,omitempty"`
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/patchwork-toolkit/patchwork/catalog/httputil"
+)
+
+var ErrNotFound = errors.New("service: entry not found")
+var ErrAlreadyExists = errors.New("service: entry already exists")
+
+const subscriberBacklog = 64
+
+// CatalogStorage is the backing store for the service catalog, keyed by the
+// registering host id. Implementations must be safe for concurrent use.
+type CatalogStorage interface {
+	Add(s Service) error
+	Update(host string, s Service) error
+	Delete(host string) error
+	Get(host string) (Service, error)
+	// List returns up to n services with Host greater than last (or from
+	// the beginning when last is empty), in ascending Host order, followed
+	// by whether more entries remain beyond the returned page.
+	List(n int, last string) (services []Service, hasMore bool, err error)
+	Filter(path, op, value string) ([]Service, error)
+	Subscribe() (events <-chan Event, cancel func())
+}
+
+// CatalogMemoryStorage is an in-memory CatalogStorage.
+type CatalogMemoryStorage struct {
+	mutex sync.RWMutex
+	data  map[string]Service
+	hosts []string
+
+	subMutex sync.Mutex
+	subs     map[chan Event]struct{}
+}
+
+func NewCatalogMemoryStorage() *CatalogMemoryStorage {
+	return &CatalogMemoryStorage{
+		data: make(map[string]Service),
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+func (self *CatalogMemoryStorage) Add(s Service) error {
+	self.mutex.Lock()
+	if _, exists := self.data[s.Host]; exists {
+		self.mutex.Unlock()
+		return ErrAlreadyExists
+	}
+	self.hosts = append(self.hosts, s.Host)
+	sort.Strings(self.hosts)
+	self.data[s.Host] = s
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeCreate, Host: s.Host, Service: &s})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Update(host string, s Service) error {
+	self.mutex.Lock()
+	if _, exists := self.data[host]; !exists {
+		self.mutex.Unlock()
+		return ErrNotFound
+	}
+	s.Host = host
+	self.data[host] = s
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeUpdate, Host: host, Service: &s})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Delete(host string) error {
+	self.mutex.Lock()
+	if _, exists := self.data[host]; !exists {
+		self.mutex.Unlock()
+		return ErrNotFound
+	}
+	delete(self.data, host)
+	for i, existing := range self.hosts {
+		if existing == host {
+			self.hosts = append(self.hosts[:i], self.hosts[i+1:]...)
+			break
+		}
+	}
+	self.mutex.Unlock()
+
+	self.broadcast(Event{Type: EventTypeDelete, Host: host})
+	return nil
+}
+
+func (self *CatalogMemoryStorage) Get(host string) (Service, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	s, exists := self.data[host]
+	if !exists {
+		return Service{}, ErrNotFound
+	}
+	return s, nil
+}
+
+func (self *CatalogMemoryStorage) List(n int, last string) ([]Service, bool, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	start, end, hasMore := httputil.PageBounds(self.hosts, n, last)
+
+	services := make([]Service, 0, end-start)
+	for _, host := range self.hosts[start:end] {
+		services = append(services, self.data[host])
+	}
+	return services, hasMore, nil
+}
+
+func (self *CatalogMemoryStorage) Filter(path, op, value string) ([]Service, error) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	var matches []Service
+	for _, host := range self.hosts {
+		s := self.data[host]
+		v, ok := fieldByPath(s, path)
+		if !ok {
+			continue
+		}
+		if httputil.MatchOp(v, op, value) {
+			matches = append(matches, s)
+		}
+	}
+	return matches, nil
+}
+
+func (self *CatalogMemoryStorage) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBacklog)
+
+	self.subMutex.Lock()
+	self.subs[ch] = struct{}{}
+	self.subMutex.Unlock()
+
+	cancel := func() {
+		self.subMutex.Lock()
+		if _, ok := self.subs[ch]; ok {
+			delete(self.subs, ch)
+			close(ch)
+		}
+		self.subMutex.Unlock()
+	}
+	return ch, cancel
+}
+
+func (self *CatalogMemoryStorage) broadcast(e Event) {
+	self.subMutex.Lock()
+	defer self.subMutex.Unlock()
+	for ch := range self.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func fieldByPath(s Service, path string) (string, bool) {
+	switch path {
+	case "host":
+		return s.Host, true
+	case "type":
+		return s.Type, true
+	case "name":
+		return s.Name, true
+	case "description":
+		return s.Description, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import "testing"
+
+func TestCatalogMemoryStorageAddDuplicate(t *testing.T) {
+	s := NewCatalogMemoryStorage()
+	if err := s.Add(Service{Host: "h1", Name: "first"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := s.Add(Service{Host: "h1", Name: "second"}); err != ErrAlreadyExists {
+		t.Fatalf("Add duplicate: got %v, want ErrAlreadyExists", err)
+	}
+
+	got, err := s.Get("h1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "first" {
+		t.Errorf("Add duplicate must not overwrite: Name = %q, want %q", got.Name, "first")
+	}
+}
+
+func TestCatalogMemoryStorageFilterContains(t *testing.T) {
+	s := NewCatalogMemoryStorage()
+	s.Add(Service{Host: "h1", Name: "kitchen-sensor"})
+	s.Add(Service{Host: "h2", Name: "garage-sensor"})
+
+	matches, err := s.Filter("name", "contains", "kitchen")
+	if err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Host != "h1" {
+		t.Errorf("Filter contains = %v, want [h1]", matches)
+	}
+}
@@ -0,0 +1,161 @@
+// Package client provides the runtime transport shared by generated
+// patchwork clients (see cmd/patchwork-gen): context-aware requests,
+// retries with jitter and a pooled HTTP connection, so the generated code
+// itself stays a thin, one-method-per-resource wrapper.
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxRetries is used when a Client is created without an explicit
+// retry count.
+const DefaultMaxRetries = 3
+
+// StatusError is returned by Get/Put when the gateway responds with a
+// non-2xx status, so doWithRetry can tell a permanent client error (4xx)
+// apart from a transient one worth retrying.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %s: %s", e.Status, e.Body)
+}
+
+// temporary reports whether the failed request is worth retrying: network
+// errors and 5xx responses are, a 4xx is a permanent client error.
+func temporary(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return !ok || statusErr.StatusCode >= 500
+}
+
+// Client is the shared transport for one gateway's generated API. Generated
+// methods call Get/Put with the resource's URL path and content type.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	MaxRetries int
+}
+
+// Option configures a Client created with New.
+type Option func(*Client)
+
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.HTTPClient = h }
+}
+
+func WithMaxRetries(n int) Option {
+	return func(c *Client) { c.MaxRetries = n }
+}
+
+// New creates a Client for the gateway at baseURL (e.g.
+// "http://gateway.local:8080/rest"), with a pooled transport suitable for
+// reuse across many generated method calls.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		MaxRetries: DefaultMaxRetries,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get performs a GET against path and returns the raw response body along
+// with the response Content-Type.
+func (self *Client) Get(ctx context.Context, path string) ([]byte, string, error) {
+	var body []byte
+	var contentType string
+	err := self.doWithRetry(ctx, func() error {
+		req, err := http.NewRequest("GET", self.BaseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := self.HTTPClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 300 {
+			return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(b)}
+		}
+		body = b
+		contentType = resp.Header.Get("Content-Type")
+		return nil
+	})
+	return body, contentType, err
+}
+
+// Put performs a PUT of body against path with the given content type.
+func (self *Client) Put(ctx context.Context, path, contentType string, body []byte) error {
+	return self.doWithRetry(ctx, func() error {
+		req, err := http.NewRequest("PUT", self.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := self.HTTPClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		if resp.StatusCode >= 300 {
+			return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(b)}
+		}
+		return nil
+	})
+}
+
+// doWithRetry runs fn, retrying transient failures with exponential backoff
+// (100ms, doubling on every attempt; the backoff has no upper bound) plus a
+// random jitter of up to the same amount, and gives up early if ctx is
+// cancelled.
+func (self *Client) doWithRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= self.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * 100 * time.Millisecond
+			jitter := time.Duration(rand.Int63n(int64(backoff)))
+			select {
+			case <-time.After(backoff + jitter):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !temporary(err) {
+			return err
+		}
+	}
+	return err
+}
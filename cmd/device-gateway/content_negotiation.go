@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptEntry is one parsed entry of an Accept header.
+type acceptEntry struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses an Accept header into its entries, defaulting a
+// missing q to 1.0. An empty header parses to no entries.
+func parseAccept(header string) []acceptEntry {
+	if header == "" {
+		return nil
+	}
+
+	var entries []acceptEntry
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.TrimSpace(fields[0])
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		entries = append(entries, acceptEntry{mediaType: mediaType, q: q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+	return entries
+}
+
+// acceptMatches reports whether an Accept entry (possibly "*/*" or
+// "type/*") matches a concrete offered media type.
+func acceptMatches(accept, offered string) bool {
+	if accept == "*/*" || accept == offered {
+		return true
+	}
+	acceptType := strings.SplitN(accept, "/", 2)
+	offeredType := strings.SplitN(offered, "/", 2)
+	return len(acceptType) == 2 && len(offeredType) == 2 &&
+		acceptType[0] == offeredType[0] && acceptType[1] == "*"
+}
+
+// negotiateAccept picks the best of offered (in the order given, as a
+// tie-break) for an Accept header. A missing/empty header accepts the
+// first offered media type, matching the common "no preference" case.
+func negotiateAccept(header string, offered []string) (string, bool) {
+	if len(offered) == 0 {
+		return "", false
+	}
+
+	entries := parseAccept(header)
+	if len(entries) == 0 {
+		return offered[0], true
+	}
+
+	for _, e := range entries {
+		if e.q <= 0 {
+			continue
+		}
+		for _, o := range offered {
+			if acceptMatches(e.mediaType, o) {
+				return o, true
+			}
+		}
+	}
+	return "", false
+}
+
+// resourceContentTypes returns the content types a resource's REST
+// protocol entries declare, in the order they are configured. The first
+// entry is treated as the resource's native representation: what the
+// device driver itself produces and consumes.
+func resourceContentTypes(resource Resource) []string {
+	var types []string
+	for _, p := range resource.Protocols {
+		if p.Type != ProtocolTypeREST || p.ContentType == "" {
+			continue
+		}
+		types = append(types, p.ContentType)
+	}
+	return types
+}
+
+// contentTypeSupported reports whether contentType is one of offered.
+func contentTypeSupported(contentType string, offered []string) bool {
+	for _, o := range offered {
+		if o == contentType {
+			return true
+		}
+	}
+	return false
+}
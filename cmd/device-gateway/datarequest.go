@@ -0,0 +1,32 @@
+package main
+
+// DataRequestType identifies whether a DataRequest reads or writes a
+// resource.
+type DataRequestType string
+
+const (
+	DataRequestTypeRead  DataRequestType = "read"
+	DataRequestTypeWrite DataRequestType = "write"
+)
+
+// DataRequest is submitted on RESTfulAPI.dataCh and picked up by the
+// gateway's agent loop, which talks to the actual device driver and
+// replies on Reply.
+//
+// Cancel is optional: when set (by an asynchronous request, see
+// RESTfulAPI.respondAsync), the agent loop must select on it alongside
+// doing the device I/O itself, and abort without sending on Reply if it
+// closes first.
+type DataRequest struct {
+	ResourceId string
+	Type       DataRequestType
+	Arguments  []byte
+	Reply      chan AgentResponse
+	Cancel     chan struct{}
+}
+
+// AgentResponse is the agent loop's reply to a DataRequest.
+type AgentResponse struct {
+	IsError bool
+	Payload []byte
+}
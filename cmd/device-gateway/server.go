@@ -13,8 +13,14 @@ import (
 	"time"
 
 	catalog "github.com/patchwork-toolkit/patchwork/catalog/device"
+	"github.com/patchwork-toolkit/patchwork/discovery/multicast"
+	"github.com/patchwork-toolkit/patchwork/operations"
+	"github.com/patchwork-toolkit/patchwork/transcoder"
 )
 
+// OperationsLocation is the mount point for the asynchronous operations API.
+const OperationsLocation = "/operations"
+
 // errorResponse used to serialize errors into JSON for RESTful responses
 type errorResponse struct {
 	Error string `json:"error"`
@@ -27,6 +33,8 @@ type RESTfulAPI struct {
 	restConfig *RestProtocol
 	serverMux  *http.ServeMux
 	dataCh     chan<- DataRequest
+	operations *operations.Registry
+	multicast  *multicast.Listener
 }
 
 // Constructs a RESTfulAPI data structure
@@ -38,6 +46,7 @@ func newRESTfulAPI(conf *Config, dataCh chan<- DataRequest) *RESTfulAPI {
 		restConfig: &restConfig,
 		serverMux:  http.NewServeMux(),
 		dataCh:     dataCh,
+		operations: operations.NewRegistry(operations.NewID),
 	}
 	return api
 }
@@ -46,6 +55,7 @@ func newRESTfulAPI(conf *Config, dataCh chan<- DataRequest) *RESTfulAPI {
 func (self *RESTfulAPI) start(catalogStorage catalog.CatalogStorage) {
 	self.mountCatalog(catalogStorage)
 	self.mountResources()
+	self.mountOperations()
 	self.serverMux.Handle("/dashboard", self.dashboardHandler(*confPath))
 	self.serverMux.Handle(self.restConfig.Location, self.indexHandler())
 	self.serverMux.Handle(StaticLocation, self.staticHandler())
@@ -64,6 +74,29 @@ func (self *RESTfulAPI) start(catalogStorage catalog.CatalogStorage) {
 		return
 	}
 
+	if self.config.Discovery.Multicast.Enabled {
+		catalogURL := fmt.Sprintf("http://%v%v", addr, CatalogLocation)
+		listener, err := multicast.Start(
+			self.config.Discovery.Multicast,
+			self.config.Id,
+			catalogURL,
+			self.config.Description,
+			func() int {
+				n := 0
+				for _, device := range self.config.Devices {
+					n += len(device.Resources)
+				}
+				return n
+			},
+			catalogStorage,
+		)
+		if err != nil {
+			log.Printf("Failed to start multicast discovery: %v", err)
+		} else {
+			self.multicast = listener
+		}
+	}
+
 	log.Printf("Starting server at http://%v%v", addr, self.restConfig.Location)
 
 	err = s.Serve(ln)
@@ -139,26 +172,96 @@ func (self *RESTfulAPI) staticHandler() http.HandlerFunc {
 func (self *RESTfulAPI) mountResources() {
 	for _, device := range self.config.Devices {
 		for _, resource := range device.Resources {
+			uri := self.restConfig.Location + "/" + device.Name + "/" + resource.Name
+			rid := device.ResourceId(resource.Name)
+
+			// A resource can declare more than one REST protocol entry to
+			// offer several content types for negotiation (see
+			// resourceContentTypes); they all still mount under the same
+			// uri, so track which methods have already been registered on
+			// it to avoid a duplicate ServeMux.Handle panic.
+			mounted := make(map[string]bool)
 			for _, protocol := range resource.Protocols {
 				if protocol.Type != ProtocolTypeREST {
 					continue
 				}
-				uri := self.restConfig.Location + "/" + device.Name + "/" + resource.Name
-				log.Println("RESTfulAPI: Mounting resource:", uri)
-				rid := device.ResourceId(resource.Name)
 				for _, method := range protocol.Methods {
+					if mounted[method] {
+						continue
+					}
 					switch method {
 					case "GET":
 						self.serverMux.Handle(uri, self.createResourceGetHandler(rid))
 					case "PUT":
 						self.serverMux.Handle(uri, self.createResourcePutHandler(rid))
+					default:
+						continue
 					}
+					mounted[method] = true
 				}
 			}
+			if len(mounted) > 0 {
+				log.Println("RESTfulAPI: Mounting resource:", uri)
+			}
 		}
 	}
 }
 
+// mountOperations exposes the asynchronous operations registry: listing,
+// polling, long-poll waiting and cancelling.
+//
+// http.ServeMux only does exact or prefix ("/foo/") matching, it does not
+// parse path parameters, so /operations/<id> and /operations/<id>/wait are
+// mounted as a single subtree handler that extracts <id> (and the
+// trailing "wait") from req.URL.Path itself.
+func (self *RESTfulAPI) mountOperations() {
+	opsAPI := operations.NewAPI(self.operations)
+
+	self.serverMux.Handle(OperationsLocation, http.HandlerFunc(opsAPI.List))
+	self.serverMux.Handle(OperationsLocation+"/", self.operationsItemHandler(opsAPI))
+
+	log.Printf("Mounted operations API at %v", OperationsLocation)
+}
+
+// operationsItemHandler routes everything under OperationsLocation+"/" by
+// hand: "<id>" to Get/Delete depending on method, "<id>/wait" to Wait.
+func (self *RESTfulAPI) operationsItemHandler(opsAPI *operations.API) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(req.URL.Path, OperationsLocation+"/"), "/")
+		if rest == "" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		parts := strings.Split(rest, "/")
+
+		q := req.URL.Query()
+		q.Set(":id", parts[0])
+		req.URL.RawQuery = q.Encode()
+
+		switch {
+		case len(parts) == 1:
+			switch req.Method {
+			case "GET":
+				opsAPI.Get(rw, req)
+			case "DELETE":
+				opsAPI.Delete(rw, req)
+			default:
+				rw.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case len(parts) == 2 && parts[1] == "wait":
+			opsAPI.Wait(rw, req)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+// isAsyncRequest reports whether the client asked for an asynchronous
+// response, either via the Prefer: respond-async header or ?async=1.
+func isAsyncRequest(req *http.Request) bool {
+	return req.Header.Get("Prefer") == "respond-async" || req.URL.Query().Get("async") == "1"
+}
+
 func (self *RESTfulAPI) mountCatalog(catalogStorage catalog.CatalogStorage) {
 	catalogAPI := catalog.NewReadableCatalogAPI(catalogStorage, CatalogLocation, StaticLocation,
 		fmt.Sprintf("Local catalog at %s", self.config.Description))
@@ -177,6 +280,8 @@ func (self *RESTfulAPI) mountCatalog(catalogStorage catalog.CatalogStorage) {
 
 	self.serverMux.Handle(CatalogLocation, http.HandlerFunc(catalogAPI.List))
 
+	self.serverMux.Handle(CatalogLocation+"/events", http.HandlerFunc(catalogAPI.Events))
+
 	log.Printf("Mounted local catalog at %v", CatalogLocation)
 }
 
@@ -184,31 +289,27 @@ func (self *RESTfulAPI) createResourceGetHandler(resourceId string) http.Handler
 	return func(rw http.ResponseWriter, req *http.Request) {
 		log.Printf("RESTfulAPI: %s %s", req.Method, req.RequestURI)
 
-		// Resolve mediaType
-		v := req.Header.Get("Content-Type")
-		mediaType, _, err := mime.ParseMediaType(v)
-		if err != nil {
-			self.respondWithBadRequest(rw, err.Error())
-			return
-		}
-
-		// Check if mediaType is supported by resource
-		isSupported := false
 		resource, found := self.config.FindResource(resourceId)
 		if !found {
 			self.respondWithNotFound(rw, "Resource does not exist")
 			return
 		}
-		for _, p := range resource.Protocols {
-			if p.Type == ProtocolTypeREST {
-				isSupported = true
-			}
-		}
-		if !isSupported {
+
+		offered := resourceContentTypes(resource)
+		if len(offered) == 0 {
 			self.respondWithUnsupportedMediaType(rw, "Media type is not supported by this resource")
 			return
 		}
 
+		// Negotiate the response representation against Accept, falling
+		// back to the resource's native content type when the client does
+		// not send one.
+		responseType, ok := negotiateAccept(req.Header.Get("Accept"), offered)
+		if !ok {
+			self.respondWithNotAcceptable(rw, "None of the resource's content types satisfy Accept")
+			return
+		}
+
 		// Retrieve data
 		dr := DataRequest{
 			ResourceId: resourceId,
@@ -216,46 +317,89 @@ func (self *RESTfulAPI) createResourceGetHandler(resourceId string) http.Handler
 			Arguments:  nil,
 			Reply:      make(chan AgentResponse),
 		}
+
+		if isAsyncRequest(req) {
+			self.respondAsync(rw, dr)
+			return
+		}
+
 		self.dataCh <- dr
 
 		// Wait for the response
 		repl := <-dr.Reply
-
-		// Response to client
-		rw.Header().Set("Content-Type", mediaType)
 		if repl.IsError {
 			self.respondWithInternalServerError(rw, string(repl.Payload))
 			return
 		}
-		rw.Write(repl.Payload)
+
+		// The agent always produces the resource's native representation
+		// (offered[0]); transcode it to whatever the client negotiated.
+		payload, err := transcoder.Transcode(offered[0], responseType, repl.Payload)
+		if err != nil {
+			self.respondWithInternalServerError(rw, err.Error())
+			return
+		}
+
+		rw.Header().Set("Content-Type", responseType)
+		rw.Write(payload)
 	}
 }
 
+// respondAsync submits dr on the data channel, registers an Operation that
+// tracks its completion in the background, and immediately replies
+// 202 Accepted with a Location header pointing at the operation.
+//
+// dr.Cancel is set to a fresh channel before submission: cancelling the
+// operation closes it, which the agent loop selects on (alongside the
+// request it read off self.dataCh) to actually abort in-flight device I/O,
+// not just stop the HTTP side from waiting on dr.Reply.
+func (self *RESTfulAPI) respondAsync(rw http.ResponseWriter, dr DataRequest) {
+	cancelled := make(chan struct{})
+	dr.Cancel = cancelled
+	op := self.operations.Create(dr.ResourceId, string(dr.Type), func() { close(cancelled) })
+
+	self.dataCh <- dr
+
+	go func() {
+		select {
+		case repl := <-dr.Reply:
+			if repl.IsError {
+				op.Complete(nil, fmt.Errorf("%s", string(repl.Payload)))
+			} else {
+				op.Complete(repl.Payload, nil)
+			}
+		case <-cancelled:
+		}
+	}()
+
+	rw.Header().Set("Location", OperationsLocation+"/"+op.ID)
+	rw.WriteHeader(http.StatusAccepted)
+}
+
 func (self *RESTfulAPI) createResourcePutHandler(resourceId string) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
 		log.Printf("RESTfulAPI: %s %s", req.Method, req.RequestURI)
 
-		// Resolve mediaType
-		v := req.Header.Get("Content-Type")
-		mediaType, _, err := mime.ParseMediaType(v)
-		if err != nil {
-			self.respondWithBadRequest(rw, err.Error())
-			return
-		}
-
-		// Check if mediaType is supported by resource
-		isSupported := false
 		resource, found := self.config.FindResource(resourceId)
 		if !found {
 			self.respondWithNotFound(rw, "Resource does not exist")
 			return
 		}
-		for _, p := range resource.Protocols {
-			if p.Type == ProtocolTypeREST {
-				isSupported = true
-			}
+
+		offered := resourceContentTypes(resource)
+		if len(offered) == 0 {
+			self.respondWithUnsupportedMediaType(rw, "Media type is not supported by this resource")
+			return
 		}
-		if !isSupported {
+
+		// Resolve and validate the request's mediaType against the same
+		// content types the resource offers on GET.
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			self.respondWithBadRequest(rw, err.Error())
+			return
+		}
+		if !contentTypeSupported(mediaType, offered) {
 			self.respondWithUnsupportedMediaType(rw, "Media type is not supported by this resource")
 			return
 		}
@@ -268,6 +412,14 @@ func (self *RESTfulAPI) createResourcePutHandler(resourceId string) http.Handler
 			return
 		}
 
+		// The agent always expects the resource's native representation
+		// (offered[0]); transcode the request body to it.
+		body, err = transcoder.Transcode(mediaType, offered[0], body)
+		if err != nil {
+			self.respondWithBadRequest(rw, err.Error())
+			return
+		}
+
 		// Submit data request
 		dr := DataRequest{
 			ResourceId: resourceId,
@@ -276,13 +428,16 @@ func (self *RESTfulAPI) createResourcePutHandler(resourceId string) http.Handler
 			Reply:      make(chan AgentResponse),
 		}
 		log.Printf("RESTfulAPI: Submitting data request %#v", dr)
+
+		if isAsyncRequest(req) {
+			self.respondAsync(rw, dr)
+			return
+		}
+
 		self.dataCh <- dr
 
 		// Wait for the response
 		repl := <-dr.Reply
-
-		// Respond to client
-		rw.Header().Set("Content-Type", mediaType)
 		if repl.IsError {
 			self.respondWithInternalServerError(rw, string(repl.Payload))
 			return
@@ -315,6 +470,14 @@ func (self *RESTfulAPI) respondWithUnsupportedMediaType(rw http.ResponseWriter,
 	rw.Write(b)
 }
 
+func (self *RESTfulAPI) respondWithNotAcceptable(rw http.ResponseWriter, msg string) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusNotAcceptable)
+	err := &errorResponse{Error: msg}
+	b, _ := json.Marshal(err)
+	rw.Write(b)
+}
+
 func (self *RESTfulAPI) respondWithInternalServerError(rw http.ResponseWriter, msg string) {
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,33 @@
+package main
+
+// The structs below mirror the subset of the device-gateway JSON
+// configuration schema (see cmd/device-gateway) that the generator needs:
+// enough to walk every device/resource/protocol tuple and know its REST
+// path, methods and content type.
+
+type protocol struct {
+	Type        string   `json:"type"`
+	Methods     []string `json:"methods"`
+	ContentType string   `json:"content-type"`
+}
+
+type resource struct {
+	Name      string     `json:"name"`
+	Protocols []protocol `json:"protocols"`
+}
+
+type device struct {
+	Name      string     `json:"name"`
+	Resources []resource `json:"resources"`
+}
+
+type restProtocol struct {
+	Location string `json:"location"`
+}
+
+type gatewayConfig struct {
+	Devices   []device                `json:"devices"`
+	Protocols map[string]restProtocol `json:"protocols"`
+}
+
+const protocolTypeREST = "REST"
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// endpoint is one generated method: a Read (GET) or a Write (PUT) against a
+// single device/resource/protocol tuple.
+type endpoint struct {
+	MethodName  string
+	Path        string
+	ContentType string
+	IsWrite     bool
+}
+
+var fileTemplate = template.Must(template.New("client").Parse(`// Code generated by patchwork-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+{{if .UsesTranscoder}}
+	"github.com/patchwork-toolkit/patchwork/client"
+	"github.com/patchwork-toolkit/patchwork/transcoder"
+{{else}}
+	"github.com/patchwork-toolkit/patchwork/client"
+{{end}}
+)
+
+// Client is a generated wrapper around client.Client: one method per
+// device/resource/protocol tuple declared in the gateway configuration this
+// file was generated from. The gateway configuration only declares each
+// resource's wire content type, not a Go type for its payload, so a
+// resource with a known content type decodes/encodes into the generic
+// transcoder representation (map[string]interface{}, []interface{},
+// float64, string, bool or nil) rather than a resource-specific struct; a
+// resource with no declared content type falls back to raw bytes.
+type Client struct {
+	*client.Client
+}
+
+func New(baseURL string, opts ...client.Option) *Client {
+	return &Client{client.New(baseURL, opts...)}
+}
+{{range .Endpoints}}
+{{if .IsWrite -}}
+{{if .ContentType -}}
+func (c *Client) {{.MethodName}}(ctx context.Context, v interface{}) error {
+	b, err := transcoder.Encode("{{.ContentType}}", v)
+	if err != nil {
+		return err
+	}
+	return c.Put(ctx, "{{.Path}}", "{{.ContentType}}", b)
+}
+{{- else -}}
+func (c *Client) {{.MethodName}}(ctx context.Context, v []byte) error {
+	return c.Put(ctx, "{{.Path}}", "{{.ContentType}}", v)
+}
+{{- end}}
+{{- else -}}
+{{if .ContentType -}}
+func (c *Client) {{.MethodName}}(ctx context.Context) (interface{}, error) {
+	b, contentType, err := c.Get(ctx, "{{.Path}}")
+	if err != nil {
+		return nil, err
+	}
+	return transcoder.Decode(contentType, b)
+}
+{{- else -}}
+func (c *Client) {{.MethodName}}(ctx context.Context) ([]byte, error) {
+	b, _, err := c.Get(ctx, "{{.Path}}")
+	return b, err
+}
+{{- end}}
+{{- end}}
+{{end -}}`))
+
+// endpoints walks every device/resource/REST-protocol tuple in cfg and
+// returns one endpoint per method it declares, in a stable (sorted) order
+// so regenerating from the same config is byte-for-byte reproducible.
+func endpoints(cfg gatewayConfig) []endpoint {
+	location := cfg.Protocols[protocolTypeREST].Location
+
+	var eps []endpoint
+	for _, d := range cfg.Devices {
+		for _, r := range d.Resources {
+			for _, p := range r.Protocols {
+				if p.Type != protocolTypeREST {
+					continue
+				}
+				path := location + "/" + d.Name + "/" + r.Name
+				base := goIdentifier(d.Name) + goIdentifier(r.Name)
+
+				for _, m := range p.Methods {
+					switch m {
+					case "GET":
+						eps = append(eps, endpoint{MethodName: base + "Read", Path: path, ContentType: p.ContentType})
+					case "PUT":
+						eps = append(eps, endpoint{MethodName: base + "Write", Path: path, ContentType: p.ContentType, IsWrite: true})
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(eps, func(i, j int) bool { return eps[i].MethodName < eps[j].MethodName })
+	return eps
+}
+
+// generate renders the full Go source file for cfg's devices, in package
+// pkgName.
+func generate(cfg gatewayConfig, pkgName string) ([]byte, error) {
+	eps := endpoints(cfg)
+	usesTranscoder := false
+	for _, e := range eps {
+		if e.ContentType != "" {
+			usesTranscoder = true
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	data := struct {
+		Package        string
+		Endpoints      []endpoint
+		UsesTranscoder bool
+	}{Package: pkgName, Endpoints: eps, UsesTranscoder: usesTranscoder}
+
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("patchwork-gen: %v", err)
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("patchwork-gen: formatting generated source: %v", err)
+	}
+	return out, nil
+}
+
+// goIdentifier turns an arbitrary resource/device name into a Go-safe,
+// exported identifier fragment, e.g. "temperature-sensor" -> "TemperatureSensor".
+func goIdentifier(name string) string {
+	var b strings.Builder
+	upperNext := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upperNext = true
+			continue
+		}
+		if upperNext {
+			b.WriteRune(unicode.ToUpper(r))
+			upperNext = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// TestGenerateGoldenFile regenerates a client from testdata/fixture-config.json
+// and diffs the result against testdata/golden_client.go. Update the golden
+// file by hand (it is small and gofmt'd) whenever the template changes.
+func TestGenerateGoldenFile(t *testing.T) {
+	confBytes, err := ioutil.ReadFile("testdata/fixture-config.json")
+	if err != nil {
+		t.Fatalf("reading fixture config: %v", err)
+	}
+
+	var cfg gatewayConfig
+	if err := json.Unmarshal(confBytes, &cfg); err != nil {
+		t.Fatalf("parsing fixture config: %v", err)
+	}
+
+	got, err := generate(cfg, "generated")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	want, err := ioutil.ReadFile("testdata/golden_client.go")
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated client does not match golden file:\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
@@ -75,6 +75,8 @@ func main() {
 
 	m.Get(catalog.CatalogBaseUrl, http.HandlerFunc(api.List))
 
+	m.Get(catalog.CatalogBaseUrl+"/events", http.HandlerFunc(api.Events))
+
 	// static
 	m.Get("/static/", http.HandlerFunc(staticHandler))
 
@@ -0,0 +1,317 @@
+// Package multicast implements IPv6 multicast announcement and discovery
+// of device gateways, as a lightweight alternative to DNS-SD for networks
+// where mDNS is unavailable or undesirable. Every gateway periodically
+// beacons its presence to a well-known site-local group and answers
+// unicast WHO-HAS probes; beacons received from peers are mirrored into a
+// catalog.CatalogStorage as remote, TTL-expiring entries.
+package multicast
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	device "github.com/patchwork-toolkit/patchwork/catalog/device"
+)
+
+// DefaultGroup is a placeholder site-local multicast group standing in for
+// the "ff05::patchwork" address used in the design: any real deployment
+// should pick an address assigned for its own use.
+const DefaultGroup = "ff05::5061:7463:6877:726b"
+
+const DefaultPort = 5683
+
+// Config controls whether the multicast subsystem runs and how. Interval is
+// in seconds rather than a time.Duration: time.Duration has no custom
+// UnmarshalJSON, so a config author writing "interval": 30 would silently
+// get 30 nanoseconds instead of 30 seconds.
+type Config struct {
+	Enabled  bool   `json:"enabled"`
+	Group    string `json:"group"`
+	Port     int    `json:"port"`
+	HopLimit int    `json:"hopLimit"`
+	Loopback bool   `json:"loopback"`
+	Interval int    `json:"interval"`
+	Ttl      int    `json:"ttl"`
+}
+
+// beaconType distinguishes the two small messages this package exchanges.
+type beaconType string
+
+const (
+	typeBeacon beaconType = "beacon"
+	typeWhoHas beaconType = "who-has"
+)
+
+// message is the wire format for both beacons and WHO-HAS probes.
+type message struct {
+	Type          beaconType `json:"type"`
+	GatewayID     string     `json:"gatewayId,omitempty"`
+	CatalogURL    string     `json:"catalogUrl,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	ResourceCount int        `json:"resourceCount,omitempty"`
+	Ttl           int        `json:"ttl,omitempty"`
+	Seq           uint64     `json:"seq,omitempty"`
+}
+
+// Listener runs the announce/discover loops for one gateway.
+type Listener struct {
+	cfg         Config
+	gatewayID   string
+	catalogURL  string
+	description string
+	resources   func() int
+	storage     device.CatalogStorage
+	interval    time.Duration
+
+	conn    *net.UDPConn
+	group   *net.UDPAddr
+	joined  map[string]bool // interface name -> currently joined
+	seq     uint64
+	seqLock sync.Mutex
+
+	peerMutex sync.Mutex
+	peerSeq   map[string]uint64
+	peerTimer map[string]*time.Timer
+
+	stop chan struct{}
+}
+
+// Start opens the multicast socket, joins the group on every non-loopback
+// interface, and launches the announce/listen/rejoin goroutines. The
+// returned Listener should be stopped with Close when the gateway shuts
+// down.
+func Start(cfg Config, gatewayID, catalogURL, description string, resources func() int, storage device.CatalogStorage) (*Listener, error) {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	if cfg.Group == "" {
+		cfg.Group = DefaultGroup
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 30
+	}
+	if cfg.Ttl == 0 {
+		cfg.Ttl = 3 * cfg.Interval
+	}
+	interval := time.Duration(cfg.Interval) * time.Second
+
+	group := &net.UDPAddr{IP: net.ParseIP(cfg.Group), Port: cfg.Port}
+
+	conn, err := net.ListenUDP("udp6", &net.UDPAddr{Port: cfg.Port})
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Listener{
+		cfg:         cfg,
+		gatewayID:   gatewayID,
+		catalogURL:  catalogURL,
+		description: description,
+		resources:   resources,
+		storage:     storage,
+		interval:    interval,
+		conn:        conn,
+		group:       group,
+		joined:      make(map[string]bool),
+		peerSeq:     make(map[string]uint64),
+		peerTimer:   make(map[string]*time.Timer),
+		stop:        make(chan struct{}),
+	}
+
+	if err := setMulticastHopLimit(l.conn, cfg.HopLimit); err != nil {
+		log.Printf("multicast: failed to set hop limit: %v", err)
+	}
+	if err := setMulticastLoopback(l.conn, cfg.Loopback); err != nil {
+		log.Printf("multicast: failed to set loopback: %v", err)
+	}
+	l.rejoinGroups()
+
+	go l.rejoinLoop()
+	go l.announceLoop()
+	go l.listenLoop()
+
+	return l, nil
+}
+
+// Close stops all goroutines and releases the socket.
+func (self *Listener) Close() error {
+	close(self.stop)
+	return self.conn.Close()
+}
+
+// rejoinGroups joins the multicast group on every non-loopback interface
+// that is not already joined, per the invariant that group membership must
+// survive interfaces coming up after startup.
+func (self *Listener) rejoinGroups() {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		log.Printf("multicast: failed to list interfaces: %v", err)
+		return
+	}
+
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagLoopback != 0 || ifi.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if self.joined[ifi.Name] {
+			continue
+		}
+		if err := joinMulticastGroup(self.conn, &ifi, self.group.IP); err != nil {
+			// Typically means the interface has no IPv6 multicast
+			// support; keep trying on the next tick.
+			continue
+		}
+		self.joined[ifi.Name] = true
+		log.Printf("multicast: joined %v on %v", self.group.IP, ifi.Name)
+	}
+}
+
+func (self *Listener) rejoinLoop() {
+	ticker := time.NewTicker(self.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			self.rejoinGroups()
+		case <-self.stop:
+			return
+		}
+	}
+}
+
+func (self *Listener) announceLoop() {
+	ticker := time.NewTicker(self.interval)
+	defer ticker.Stop()
+	for {
+		self.sendBeacon(self.group)
+		select {
+		case <-ticker.C:
+		case <-self.stop:
+			return
+		}
+	}
+}
+
+func (self *Listener) sendBeacon(to *net.UDPAddr) {
+	self.seqLock.Lock()
+	self.seq++
+	seq := self.seq
+	self.seqLock.Unlock()
+
+	msg := message{
+		Type:          typeBeacon,
+		GatewayID:     self.gatewayID,
+		CatalogURL:    self.catalogURL,
+		Description:   self.description,
+		ResourceCount: self.resources(),
+		Ttl:           self.cfg.Ttl,
+		Seq:           seq,
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	if _, err := self.conn.WriteToUDP(b, to); err != nil {
+		log.Printf("multicast: failed to send beacon: %v", err)
+	}
+}
+
+func (self *Listener) listenLoop() {
+	buf := make([]byte, 4096)
+	for {
+		select {
+		case <-self.stop:
+			return
+		default:
+		}
+
+		self.conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, src, err := self.conn.ReadFromUDP(buf)
+		if err != nil {
+			continue
+		}
+
+		var msg message
+		if err := json.Unmarshal(buf[:n], &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case typeWhoHas:
+			self.sendBeacon(src)
+		case typeBeacon:
+			if msg.GatewayID == self.gatewayID {
+				continue // our own beacon looped back
+			}
+			// Never trust the claimed address in the payload: the only
+			// address we act on is the UDP packet's own source.
+			self.handlePeerBeacon(msg, src)
+		}
+	}
+}
+
+// handlePeerBeacon mirrors a peer's beacon into the local catalog,
+// deduplicating retransmitted copies by gatewayID + monotonically
+// increasing sequence number, and arms an expiry timer driven by the
+// beacon's own ttl.
+func (self *Listener) handlePeerBeacon(msg message, src *net.UDPAddr) {
+	self.peerMutex.Lock()
+	if last, seen := self.peerSeq[msg.GatewayID]; seen && msg.Seq <= last {
+		self.peerMutex.Unlock()
+		return
+	}
+	self.peerSeq[msg.GatewayID] = msg.Seq
+	if t, armed := self.peerTimer[msg.GatewayID]; armed {
+		t.Stop()
+	}
+	self.peerTimer[msg.GatewayID] = time.AfterFunc(time.Duration(msg.Ttl)*time.Second, func() {
+		self.expirePeer(msg.GatewayID)
+	})
+	self.peerMutex.Unlock()
+
+	// Only the port and path of the claimed catalogUrl are kept; its host is
+	// replaced by the packet's source address.
+	catalogURL := msg.CatalogURL
+	if u, err := url.Parse(msg.CatalogURL); err == nil && u.Host != "" {
+		host := src.IP.String()
+		if src.Zone != "" {
+			host += "%" + src.Zone
+		}
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			u.Host = "[" + host + "]"
+		} else {
+			u.Host = host
+		}
+		catalogURL = u.String()
+	}
+
+	entry := device.Device{
+		Id:          msg.GatewayID,
+		Type:        "remote",
+		Name:        msg.Description,
+		Description: catalogURL,
+		Ttl:         msg.Ttl,
+	}
+	if err := self.storage.Add(entry); err != nil {
+		self.storage.Update(msg.GatewayID, entry)
+	}
+
+	log.Printf("multicast: discovered peer gateway %v at %v", msg.GatewayID, src.IP)
+}
+
+func (self *Listener) expirePeer(gatewayID string) {
+	self.peerMutex.Lock()
+	delete(self.peerSeq, gatewayID)
+	delete(self.peerTimer, gatewayID)
+	self.peerMutex.Unlock()
+
+	self.storage.Delete(gatewayID)
+	log.Printf("multicast: expired peer gateway %v", gatewayID)
+}
@@ -0,0 +1,47 @@
+package multicast
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	device "github.com/patchwork-toolkit/patchwork/catalog/device"
+)
+
+func newTestListener(storage device.CatalogStorage) *Listener {
+	return &Listener{
+		gatewayID: "self",
+		storage:   storage,
+		peerSeq:   make(map[string]uint64),
+		peerTimer: make(map[string]*time.Timer),
+	}
+}
+
+func TestHandlePeerBeaconDedupesBySequence(t *testing.T) {
+	storage := device.NewCatalogMemoryStorage()
+	l := newTestListener(storage)
+	src := &net.UDPAddr{IP: net.ParseIP("fe80::1")}
+
+	l.handlePeerBeacon(message{GatewayID: "peer", Description: "peer-gw", Ttl: 60, Seq: 1}, src)
+	events, cancel := storage.Subscribe()
+	defer cancel()
+
+	l.handlePeerBeacon(message{GatewayID: "peer", Description: "peer-gw", Ttl: 60, Seq: 1}, src)
+	l.handlePeerBeacon(message{GatewayID: "peer", Description: "peer-gw", Ttl: 60, Seq: 0}, src)
+
+	select {
+	case e := <-events:
+		t.Fatalf("handlePeerBeacon: unexpected event for a stale/duplicate sequence: %+v", e)
+	default:
+	}
+
+	l.handlePeerBeacon(message{GatewayID: "peer", Description: "peer-gw-2", Ttl: 60, Seq: 2}, src)
+	select {
+	case e := <-events:
+		if e.Type != device.EventTypeUpdate {
+			t.Errorf("handlePeerBeacon: event type = %v, want update (peer already known)", e.Type)
+		}
+	default:
+		t.Fatalf("handlePeerBeacon: expected an update event for a fresh sequence number")
+	}
+}
@@ -0,0 +1,59 @@
+// +build linux darwin freebsd dragonfly netbsd openbsd
+
+package multicast
+
+import (
+	"net"
+	"syscall"
+)
+
+// withRawSocket runs fn with the raw file descriptor backing conn, for the
+// IPv6 multicast socket options (group membership, hop limit, loopback)
+// that net.UDPConn does not expose itself.
+func withRawSocket(conn *net.UDPConn, fn func(fd int) error) error {
+	f, err := conn.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(int(f.Fd()))
+}
+
+// setMulticastHopLimit sets the hop limit used for outgoing multicast
+// packets on conn.
+func setMulticastHopLimit(conn *net.UDPConn, hopLimit int) error {
+	return withRawSocket(conn, func(fd int) error {
+		return syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_HOPS, hopLimit)
+	})
+}
+
+// setMulticastLoopback sets whether conn receives copies of its own
+// outgoing multicast packets.
+func setMulticastLoopback(conn *net.UDPConn, loopback bool) error {
+	v := 0
+	if loopback {
+		v = 1
+	}
+	return withRawSocket(conn, func(fd int) error {
+		return syscall.SetsockoptInt(fd, syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_LOOP, v)
+	})
+}
+
+// joinMulticastGroup joins group on interface ifi (the system-assigned
+// interface, when ifi is nil).
+func joinMulticastGroup(conn *net.UDPConn, ifi *net.Interface, group net.IP) error {
+	ip16 := group.To16()
+	if ip16 == nil {
+		return &net.AddrError{Err: "non-IPv6 multicast address", Addr: group.String()}
+	}
+
+	mreq := &syscall.IPv6Mreq{}
+	copy(mreq.Multiaddr[:], ip16)
+	if ifi != nil {
+		mreq.Interface = uint32(ifi.Index)
+	}
+
+	return withRawSocket(conn, func(fd int) error {
+		return syscall.SetsockoptIPv6Mreq(fd, syscall.IPPROTO_IPV6, syscall.IPV6_JOIN_GROUP, mreq)
+	})
+}
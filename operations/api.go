@@ -0,0 +1,94 @@
+package operations
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// NewID returns a random 128-bit hex identifier suitable for naming
+// Operations.
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// API exposes a Registry over HTTP: GET /operations, GET /operations/{id},
+// DELETE /operations/{id} (cancel) and GET /operations/{id}/wait?timeout=.
+type API struct {
+	registry *Registry
+}
+
+func NewAPI(registry *Registry) *API {
+	return &API{registry: registry}
+}
+
+func (self *API) List(rw http.ResponseWriter, req *http.Request) {
+	self.respondWithJSON(rw, self.registry.List())
+}
+
+func (self *API) Get(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":id")
+	op, found := self.registry.Get(id)
+	if !found {
+		self.respondWithNotFound(rw)
+		return
+	}
+	self.respondWithJSON(rw, op)
+}
+
+func (self *API) Delete(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":id")
+	if _, found := self.registry.Get(id); !found {
+		self.respondWithNotFound(rw)
+		return
+	}
+	if !self.registry.Cancel(id) {
+		rw.WriteHeader(http.StatusConflict)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (self *API) Wait(rw http.ResponseWriter, req *http.Request) {
+	id := req.URL.Query().Get(":id")
+	op, found := self.registry.Get(id)
+	if !found {
+		self.respondWithNotFound(rw)
+		return
+	}
+
+	var timeout time.Duration
+	if v := req.URL.Query().Get("timeout"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(secs) * time.Second
+	}
+
+	op.Wait(timeout)
+	self.respondWithJSON(rw, op)
+}
+
+func (self *API) respondWithJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	b, err := json.Marshal(v)
+	if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	rw.Write(b)
+}
+
+func (self *API) respondWithNotFound(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusNotFound)
+	b, _ := json.Marshal(map[string]string{"error": "Operation not found"})
+	rw.Write(b)
+}
@@ -0,0 +1,160 @@
+// Package operations tracks long-running DataRequests submitted to a
+// device-gateway agent, analogous to LXD's operations subsystem: every
+// asynchronous write/read gets an Operation that can be polled, waited on
+// or cancelled instead of blocking the HTTP goroutine that created it.
+package operations
+
+import (
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusSuccess   Status = "success"
+	StatusFailure   Status = "failure"
+	StatusCancelled Status = "cancelled"
+)
+
+// Operation is the state of a single in-flight or completed DataRequest.
+type Operation struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Resource  string    `json:"resource"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Result    []byte    `json:"result,omitempty"`
+	Error     string    `json:"error,omitempty"`
+
+	mutex  sync.Mutex
+	done   chan struct{}
+	cancel func()
+}
+
+// Complete marks the operation as finished with the given result. If err is
+// non-nil the operation transitions to StatusFailure instead of
+// StatusSuccess.
+func (self *Operation) Complete(result []byte, err error) {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	select {
+	case <-self.done:
+		return // already completed or cancelled
+	default:
+	}
+
+	self.Result = result
+	self.UpdatedAt = time.Now()
+	if err != nil {
+		self.Status = StatusFailure
+		self.Error = err.Error()
+	} else {
+		self.Status = StatusSuccess
+	}
+	close(self.done)
+}
+
+// Wait blocks until the operation completes or the timeout elapses,
+// returning true if it completed within the timeout. A zero timeout waits
+// forever.
+func (self *Operation) Wait(timeout time.Duration) bool {
+	if timeout <= 0 {
+		<-self.done
+		return true
+	}
+	select {
+	case <-self.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Registry is an in-memory store of Operations, keyed by ID.
+type Registry struct {
+	mutex sync.RWMutex
+	ops   map[string]*Operation
+	newID func() string
+}
+
+// NewRegistry creates an empty Registry. newID is called to mint the ID of
+// each created Operation.
+func NewRegistry(newID func() string) *Registry {
+	return &Registry{
+		ops:   make(map[string]*Operation),
+		newID: newID,
+	}
+}
+
+// Create registers a new running Operation for the given resource/type.
+// cancel is invoked if the operation is later cancelled via Delete; it
+// should close the reply channel and signal the agent side to stop work.
+func (self *Registry) Create(resourceId, opType string, cancel func()) *Operation {
+	op := &Operation{
+		ID:        self.newID(),
+		Type:      opType,
+		Resource:  resourceId,
+		Status:    StatusRunning,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		done:      make(chan struct{}),
+		cancel:    cancel,
+	}
+
+	self.mutex.Lock()
+	self.ops[op.ID] = op
+	self.mutex.Unlock()
+
+	return op
+}
+
+func (self *Registry) Get(id string) (*Operation, bool) {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+	op, found := self.ops[id]
+	return op, found
+}
+
+func (self *Registry) List() []*Operation {
+	self.mutex.RLock()
+	defer self.mutex.RUnlock()
+
+	ops := make([]*Operation, 0, len(self.ops))
+	for _, op := range self.ops {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// Cancel stops a still-running operation by invoking its cancel callback
+// and marking it StatusCancelled. It returns false if the operation does
+// not exist or has already completed.
+func (self *Registry) Cancel(id string) bool {
+	self.mutex.RLock()
+	op, found := self.ops[id]
+	self.mutex.RUnlock()
+	if !found {
+		return false
+	}
+
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+
+	select {
+	case <-op.done:
+		return false
+	default:
+	}
+
+	op.Status = StatusCancelled
+	op.UpdatedAt = time.Now()
+	close(op.done)
+	if op.cancel != nil {
+		op.cancel()
+	}
+	return true
+}
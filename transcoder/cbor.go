@@ -0,0 +1,320 @@
+package transcoder
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// cborCodec implements application/cbor (RFC 7049) for the subset of
+// values the canonical representation can hold: nil, bool, float64,
+// string, []interface{} and map[string]interface{}. It is a minimal,
+// dependency-free encoder/decoder rather than a general-purpose CBOR
+// library.
+type cborCodec struct{}
+
+func (cborCodec) Decode(data []byte) (interface{}, error) {
+	v, n, err := decodeCBOR(data)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, fmt.Errorf("cbor: %d trailing byte(s) after value", len(data)-n)
+	}
+	return v, nil
+}
+
+func (cborCodec) Encode(v interface{}) ([]byte, error) {
+	return encodeCBOR(v)
+}
+
+const (
+	cborMajorUint    = 0
+	cborMajorNegInt  = 1
+	cborMajorText    = 3
+	cborMajorArray   = 4
+	cborMajorMap     = 5
+	cborMajorSimple  = 7
+	cborAddInfoFalse = 20
+	cborAddInfoTrue  = 21
+	cborAddInfoNull  = 22
+	cborAddInfoF64   = 27
+)
+
+func encodeCBOR(v interface{}) ([]byte, error) {
+	switch val := v.(type) {
+	case nil:
+		return []byte{cborMajorSimple<<5 | cborAddInfoNull}, nil
+	case bool:
+		b := byte(cborAddInfoFalse)
+		if val {
+			b = cborAddInfoTrue
+		}
+		return []byte{cborMajorSimple<<5 | b}, nil
+	case float64:
+		return encodeCBORFloat(val), nil
+	case int:
+		return encodeCBORInt(int64(val)), nil
+	case string:
+		return encodeCBORHead(cborMajorText, uint64(len(val)), []byte(val)), nil
+	case []interface{}:
+		out := encodeCBORHead(cborMajorArray, uint64(len(val)), nil)
+		for _, item := range val {
+			b, err := encodeCBOR(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, b...)
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := encodeCBORHead(cborMajorMap, uint64(len(val)), nil)
+		for k, item := range val {
+			kb, err := encodeCBOR(k)
+			if err != nil {
+				return nil, err
+			}
+			vb, err := encodeCBOR(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, kb...)
+			out = append(out, vb...)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("cbor: unsupported type %T", v)
+	}
+}
+
+func encodeCBORInt(n int64) []byte {
+	if n >= 0 {
+		return encodeCBORHead(cborMajorUint, uint64(n), nil)
+	}
+	return encodeCBORHead(cborMajorNegInt, uint64(-n-1), nil)
+}
+
+func encodeCBORFloat(f float64) []byte {
+	b := make([]byte, 9)
+	b[0] = cborMajorSimple<<5 | cborAddInfoF64
+	binary.BigEndian.PutUint64(b[1:], math.Float64bits(f))
+	return b
+}
+
+// encodeCBORHead writes a major type + length head, followed by payload
+// (used as-is for text strings; arrays/maps append their own elements).
+func encodeCBORHead(major byte, n uint64, payload []byte) []byte {
+	var head []byte
+	switch {
+	case n < 24:
+		head = []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		head = []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		head = make([]byte, 3)
+		head[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(head[1:], uint16(n))
+	case n <= 0xffffffff:
+		head = make([]byte, 5)
+		head[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(head[1:], uint32(n))
+	default:
+		head = make([]byte, 9)
+		head[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(head[1:], n)
+	}
+	return append(head, payload...)
+}
+
+// decodeCBOR decodes a single value from the start of data and returns the
+// number of bytes it consumed.
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	if len(data) == 0 {
+		return nil, 0, fmt.Errorf("cbor: unexpected end of input")
+	}
+
+	major := data[0] >> 5
+	addInfo := data[0] & 0x1f
+
+	n, headLen, err := decodeCBORLength(data, addInfo)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case cborMajorUint:
+		return float64(n), headLen, nil
+	case cborMajorNegInt:
+		return float64(-1 - int64(n)), headLen, nil
+	case cborMajorText:
+		end := headLen + int(n)
+		if end > len(data) {
+			return nil, 0, fmt.Errorf("cbor: text string truncated")
+		}
+		return string(data[headLen:end]), end, nil
+	case cborMajorArray:
+		items := make([]interface{}, 0, n)
+		offset := headLen
+		for i := uint64(0); i < n; i++ {
+			item, consumed, err := decodeCBOR(data[offset:])
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			offset += consumed
+		}
+		return items, offset, nil
+	case cborMajorMap:
+		m := make(map[string]interface{}, n)
+		offset := headLen
+		for i := uint64(0); i < n; i++ {
+			key, consumed, err := decodeCBOR(data[offset:])
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += consumed
+			keyStr, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("cbor: only text-string map keys are supported")
+			}
+			val, consumed, err := decodeCBOR(data[offset:])
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += consumed
+			m[keyStr] = val
+		}
+		return m, offset, nil
+	case cborMajorSimple:
+		switch addInfo {
+		case cborAddInfoFalse:
+			return false, headLen, nil
+		case cborAddInfoTrue:
+			return true, headLen, nil
+		case cborAddInfoNull:
+			return nil, headLen, nil
+		case cborAddInfoF64:
+			if headLen != 9 || len(data) < 9 {
+				return nil, 0, fmt.Errorf("cbor: truncated double")
+			}
+			bits := binary.BigEndian.Uint64(data[1:9])
+			return math.Float64frombits(bits), 9, nil
+		default:
+			return nil, 0, fmt.Errorf("cbor: unsupported simple value %d", addInfo)
+		}
+	default:
+		return nil, 0, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// decodeCBORLength parses the additional-info length field following a
+// CBOR head byte, returning the length and the number of bytes the head
+// (byte 0 + any length extension) occupies.
+func decodeCBORLength(data []byte, addInfo byte) (uint64, int, error) {
+	switch {
+	case addInfo < 24:
+		return uint64(addInfo), 1, nil
+	case addInfo == 24:
+		if len(data) < 2 {
+			return 0, 0, fmt.Errorf("cbor: truncated length")
+		}
+		return uint64(data[1]), 2, nil
+	case addInfo == 25:
+		if len(data) < 3 {
+			return 0, 0, fmt.Errorf("cbor: truncated length")
+		}
+		return uint64(binary.BigEndian.Uint16(data[1:3])), 3, nil
+	case addInfo == 26:
+		if len(data) < 5 {
+			return 0, 0, fmt.Errorf("cbor: truncated length")
+		}
+		return uint64(binary.BigEndian.Uint32(data[1:5])), 5, nil
+	case addInfo == 27:
+		if len(data) < 9 {
+			return 0, 0, fmt.Errorf("cbor: truncated length")
+		}
+		return binary.BigEndian.Uint64(data[1:9]), 9, nil
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional info %d", addInfo)
+	}
+}
+
+// senmlCBORCodec implements application/senml+cbor: the same SenML record
+// shape as senmlJSONCodec, serialized with the generic cborCodec instead
+// of encoding/json.
+type senmlCBORCodec struct{}
+
+func (senmlCBORCodec) Decode(data []byte) (interface{}, error) {
+	v, err := (cborCodec{}).Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("senml+cbor: expected a CBOR array of records")
+	}
+	records := make([]senmlRecord, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("senml+cbor: expected a CBOR map per record")
+		}
+		records = append(records, recordFromMap(m))
+	}
+	return valueFromSenMLRecords(records), nil
+}
+
+func (senmlCBORCodec) Encode(v interface{}) ([]byte, error) {
+	records := senmlRecordsFromValue(v)
+	items := make([]interface{}, 0, len(records))
+	for _, r := range records {
+		items = append(items, recordToMap(r))
+	}
+	return (cborCodec{}).Encode(items)
+}
+
+func recordToMap(r senmlRecord) map[string]interface{} {
+	m := make(map[string]interface{})
+	if r.BaseName != "" {
+		m["bn"] = r.BaseName
+	}
+	if r.Name != "" {
+		m["n"] = r.Name
+	}
+	if r.Unit != "" {
+		m["u"] = r.Unit
+	}
+	if r.Value != nil {
+		m["v"] = *r.Value
+	}
+	if r.StringValue != nil {
+		m["vs"] = *r.StringValue
+	}
+	if r.BoolValue != nil {
+		m["vb"] = *r.BoolValue
+	}
+	return m
+}
+
+func recordFromMap(m map[string]interface{}) senmlRecord {
+	var r senmlRecord
+	if bn, ok := m["bn"].(string); ok {
+		r.BaseName = bn
+	}
+	if n, ok := m["n"].(string); ok {
+		r.Name = n
+	}
+	if u, ok := m["u"].(string); ok {
+		r.Unit = u
+	}
+	if v, ok := m["v"].(float64); ok {
+		r.Value = &v
+	}
+	if vs, ok := m["vs"].(string); ok {
+		r.StringValue = &vs
+	}
+	if vb, ok := m["vb"].(bool); ok {
+		r.BoolValue = &vb
+	}
+	return r
+}
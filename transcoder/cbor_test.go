@@ -0,0 +1,68 @@
+package transcoder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCBORCodecRoundTrip(t *testing.T) {
+	cases := []interface{}{
+		nil,
+		true,
+		false,
+		3.14,
+		"hello",
+		[]interface{}{1.0, "two", false},
+		map[string]interface{}{"a": 1.0, "b": "c"},
+		map[string]interface{}{
+			"nested": []interface{}{
+				map[string]interface{}{"x": 1.0},
+			},
+		},
+	}
+
+	codec := cborCodec{}
+	for _, v := range cases {
+		encoded, err := codec.Encode(v)
+		if err != nil {
+			t.Fatalf("Encode(%#v): %v", v, err)
+		}
+		decoded, err := codec.Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(Encode(%#v)): %v", v, err)
+		}
+		if !reflect.DeepEqual(v, decoded) {
+			t.Errorf("round trip mismatch: got %#v, want %#v", decoded, v)
+		}
+	}
+}
+
+func TestCBORCodecDecodeTrailingBytes(t *testing.T) {
+	encoded, err := (cborCodec{}).Encode(1.0)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, err := (cborCodec{}).Decode(append(encoded, 0xff)); err == nil {
+		t.Errorf("Decode: expected an error for trailing bytes, got nil")
+	}
+}
+
+func TestSenMLCBORCodecRoundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"temperature": 21.5,
+		"humidity":    40.0,
+	}
+
+	codec := senmlCBORCodec{}
+	encoded, err := codec.Encode(v)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	decoded, err := codec.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(v, decoded) {
+		t.Errorf("round trip mismatch: got %#v, want %#v", decoded, v)
+	}
+}
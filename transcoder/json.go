@@ -0,0 +1,19 @@
+package transcoder
+
+import "encoding/json"
+
+// jsonCodec is the identity Codec for application/json: the canonical
+// in-memory representation is already "whatever encoding/json produces".
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
@@ -0,0 +1,98 @@
+package transcoder
+
+import "encoding/json"
+
+// senmlRecord is a single entry of a SenML ([RFC 8428]) Pack: enough
+// fields to round-trip the scalar values a resource typically reads or
+// writes. A map canonical representation is carried as a single record per
+// key rather than attempting a full SenML mapping; any other value,
+// including an array, is carried as one unnamed record (see
+// scalarToRecord).
+//
+// [RFC 8428]: https://www.rfc-editor.org/rfc/rfc8428
+type senmlRecord struct {
+	BaseName    string   `json:"bn,omitempty"`
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue *string  `json:"vs,omitempty"`
+	BoolValue   *bool    `json:"vb,omitempty"`
+}
+
+// senmlRecordsFromValue wraps a canonical value into a SenML Pack. A
+// map[string]interface{} becomes one record per key (named after it); any
+// other value becomes a single unnamed record.
+func senmlRecordsFromValue(v interface{}) []senmlRecord {
+	if m, ok := v.(map[string]interface{}); ok {
+		records := make([]senmlRecord, 0, len(m))
+		for name, val := range m {
+			records = append(records, scalarToRecord(name, val))
+		}
+		return records
+	}
+	return []senmlRecord{scalarToRecord("", v)}
+}
+
+func scalarToRecord(name string, v interface{}) senmlRecord {
+	r := senmlRecord{Name: name}
+	switch val := v.(type) {
+	case float64:
+		r.Value = &val
+	case bool:
+		r.BoolValue = &val
+	case string:
+		r.StringValue = &val
+	case nil:
+		// leave all value fields unset
+	default:
+		s := ""
+		if b, err := json.Marshal(val); err == nil {
+			s = string(b)
+		}
+		r.StringValue = &s
+	}
+	return r
+}
+
+// valueFromSenMLRecords is the inverse of senmlRecordsFromValue: a single
+// unnamed record becomes its scalar value, any other set of records (one
+// named record included) becomes a map keyed by name.
+func valueFromSenMLRecords(records []senmlRecord) interface{} {
+	if len(records) == 1 && records[0].Name == "" {
+		return recordToScalar(records[0])
+	}
+
+	m := make(map[string]interface{}, len(records))
+	for _, r := range records {
+		m[r.Name] = recordToScalar(r)
+	}
+	return m
+}
+
+func recordToScalar(r senmlRecord) interface{} {
+	switch {
+	case r.Value != nil:
+		return *r.Value
+	case r.StringValue != nil:
+		return *r.StringValue
+	case r.BoolValue != nil:
+		return *r.BoolValue
+	default:
+		return nil
+	}
+}
+
+// senmlJSONCodec implements application/senml+json.
+type senmlJSONCodec struct{}
+
+func (senmlJSONCodec) Decode(data []byte) (interface{}, error) {
+	var records []senmlRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return valueFromSenMLRecords(records), nil
+}
+
+func (senmlJSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(senmlRecordsFromValue(v))
+}
@@ -0,0 +1,84 @@
+// Package transcoder converts a resource's payload between the wire
+// representations a gateway understands, so a device driver can always
+// produce one canonical content type while the HTTP layer serves whatever
+// representation the client actually asked for.
+//
+// The canonical in-memory form passed between Codecs is whatever
+// encoding/json would decode the payload into (map[string]interface{},
+// []interface{}, float64, string, bool or nil), so that adding a new
+// Codec only requires converting to and from that shape.
+package transcoder
+
+import "fmt"
+
+// Codec encodes and decodes a single content type to/from the canonical
+// in-memory representation.
+type Codec interface {
+	Decode(data []byte) (interface{}, error)
+	Encode(v interface{}) ([]byte, error)
+}
+
+var registry = map[string]Codec{
+	"application/json":       jsonCodec{},
+	"application/senml+json": senmlJSONCodec{},
+	"application/cbor":       cborCodec{},
+	"application/senml+cbor": senmlCBORCodec{},
+}
+
+// Register adds or replaces the Codec used for contentType. Call it from
+// an init() to extend the set of representations this package can
+// transcode between.
+func Register(contentType string, c Codec) {
+	registry[contentType] = c
+}
+
+// Supported reports whether contentType has a registered Codec.
+func Supported(contentType string) bool {
+	_, ok := registry[contentType]
+	return ok
+}
+
+// Decode decodes data from contentType into the canonical representation.
+func Decode(contentType string, data []byte) (interface{}, error) {
+	codec, ok := registry[contentType]
+	if !ok {
+		return nil, fmt.Errorf("transcoder: no codec registered for %q", contentType)
+	}
+	return codec.Decode(data)
+}
+
+// Encode encodes v, in the canonical representation, as contentType.
+func Encode(contentType string, v interface{}) ([]byte, error) {
+	codec, ok := registry[contentType]
+	if !ok {
+		return nil, fmt.Errorf("transcoder: no codec registered for %q", contentType)
+	}
+	return codec.Encode(v)
+}
+
+// Transcode re-encodes data from one content type to another. If from and
+// to are the same it returns data unchanged without involving a Codec.
+func Transcode(from, to string, data []byte) ([]byte, error) {
+	if from == to {
+		return data, nil
+	}
+
+	srcCodec, ok := registry[from]
+	if !ok {
+		return nil, fmt.Errorf("transcoder: no codec registered for %q", from)
+	}
+	dstCodec, ok := registry[to]
+	if !ok {
+		return nil, fmt.Errorf("transcoder: no codec registered for %q", to)
+	}
+
+	v, err := srcCodec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("transcoder: decoding %q: %v", from, err)
+	}
+	out, err := dstCodec.Encode(v)
+	if err != nil {
+		return nil, fmt.Errorf("transcoder: encoding %q: %v", to, err)
+	}
+	return out, nil
+}